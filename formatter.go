@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Formatter renders a ranked language breakdown for one user/org to w.
+// Implementations must not mutate ranked.
+type Formatter interface {
+	Format(w io.Writer, username string, ranked []langStat, excluded []string) error
+}
+
+// formatterFor resolves the -format flag / config `format` key to a
+// Formatter. Unknown names are a user error, not a silent fallback to table.
+func formatterFor(name string) (Formatter, error) {
+	switch name {
+	case "table":
+		return tableFormatter{}, nil
+	case "json":
+		return jsonFormatter{}, nil
+	case "csv":
+		return delimitedFormatter{delimiter: ','}, nil
+	case "tsv":
+		return delimitedFormatter{delimiter: '\t'}, nil
+	case "yaml":
+		return yamlFormatter{}, nil
+	case "markdown":
+		return markdownFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q: want table, json, csv, tsv, yaml, or markdown", name)
+	}
+}
+
+func percentOf(bytes, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(bytes) / float64(total) * 100
+}
+
+type tableFormatter struct{}
+
+func (tableFormatter) Format(w io.Writer, username string, ranked []langStat, excluded []string) error {
+	fmt.Fprintln(w, "Language Bytes")
+	fmt.Fprintln(w, "-------- -----")
+	for _, item := range ranked {
+		fmt.Fprintf(w, "%-8s %d\n", item.Lang, item.Bytes)
+	}
+	return nil
+}
+
+type jsonLanguage struct {
+	Lang    string  `json:"lang"`
+	Bytes   int64   `json:"bytes"`
+	Percent float64 `json:"percent"`
+}
+
+type jsonReport struct {
+	Username   string         `json:"username"`
+	TotalBytes int64          `json:"total_bytes"`
+	Excluded   []string       `json:"excluded"`
+	Languages  []jsonLanguage `json:"languages"`
+}
+
+type jsonFormatter struct{}
+
+func (jsonFormatter) Format(w io.Writer, username string, ranked []langStat, excluded []string) error {
+	total := totalBytesOf(ranked)
+	if excluded == nil {
+		excluded = []string{}
+	}
+	report := jsonReport{
+		Username:   username,
+		TotalBytes: total,
+		Excluded:   excluded,
+		Languages:  make([]jsonLanguage, 0, len(ranked)),
+	}
+	for _, item := range ranked {
+		report.Languages = append(report.Languages, jsonLanguage{
+			Lang:    item.Lang,
+			Bytes:   item.Bytes,
+			Percent: percentOf(item.Bytes, total),
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// delimitedFormatter backs both csv and tsv: same header/columns, different
+// field separator.
+type delimitedFormatter struct {
+	delimiter rune
+}
+
+func (f delimitedFormatter) Format(w io.Writer, username string, ranked []langStat, excluded []string) error {
+	total := totalBytesOf(ranked)
+
+	cw := csv.NewWriter(w)
+	cw.Comma = f.delimiter
+	if err := cw.Write([]string{"lang", "bytes", "percent"}); err != nil {
+		return err
+	}
+	for _, item := range ranked {
+		record := []string{
+			item.Lang,
+			fmt.Sprintf("%d", item.Bytes),
+			fmt.Sprintf("%.2f", percentOf(item.Bytes, total)),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type yamlFormatter struct{}
+
+func (yamlFormatter) Format(w io.Writer, username string, ranked []langStat, excluded []string) error {
+	total := totalBytesOf(ranked)
+
+	fmt.Fprintf(w, "username: %s\n", yamlScalar(username))
+	fmt.Fprintf(w, "total_bytes: %d\n", total)
+	if len(excluded) == 0 {
+		fmt.Fprintln(w, "excluded: []")
+	} else {
+		fmt.Fprintln(w, "excluded:")
+		for _, lang := range excluded {
+			fmt.Fprintf(w, "  - %s\n", yamlScalar(lang))
+		}
+	}
+	if len(ranked) == 0 {
+		fmt.Fprintln(w, "languages: []")
+		return nil
+	}
+	fmt.Fprintln(w, "languages:")
+	for _, item := range ranked {
+		fmt.Fprintf(w, "  - lang: %s\n", yamlScalar(item.Lang))
+		fmt.Fprintf(w, "    bytes: %d\n", item.Bytes)
+		fmt.Fprintf(w, "    percent: %.2f\n", percentOf(item.Bytes, total))
+	}
+	return nil
+}
+
+// yamlScalar quotes a string if it contains characters that would otherwise
+// change how a YAML parser interprets it.
+func yamlScalar(s string) string {
+	if s == "" || strings.ContainsAny(s, ":#{}[]&*!|>'\"%@`,") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
+
+type markdownFormatter struct{}
+
+func (markdownFormatter) Format(w io.Writer, username string, ranked []langStat, excluded []string) error {
+	total := totalBytesOf(ranked)
+
+	fmt.Fprintln(w, "| Language | Bytes | Percent |")
+	fmt.Fprintln(w, "| --- | --- | --- |")
+	for _, item := range ranked {
+		fmt.Fprintf(w, "| %s | %d | %.2f%% |\n", item.Lang, item.Bytes, percentOf(item.Bytes, total))
+	}
+	if len(excluded) > 0 {
+		fmt.Fprintf(w, "\nExcluded: %s\n", strings.Join(excluded, ", "))
+	}
+	return nil
+}
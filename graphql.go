@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const graphQLEndpoint = "https://api.github.com/graphql"
+
+// reposLanguagesQueryTemplate walks a user's or org's repos in one paginated
+// GraphQL query instead of the REST N+1 (list + one /languages call per
+// repo). isFork/isArchived are only included as arguments when the caller
+// wants to filter them out, since the schema treats their absence as "don't
+// filter on this field" rather than "either value".
+const reposLanguagesQueryTemplate = `
+query($login: String!, $after: String) {
+  %s(login: $login) {
+    repositories(first: 100, after: $after, ownerAffiliations: [OWNER]%s) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        languages(first: 50, orderBy: {field: SIZE, direction: DESC}) {
+          edges {
+            size
+            node { name color }
+          }
+        }
+      }
+    }
+  }
+}`
+
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+type graphQLError struct {
+	Message string `json:"message"`
+}
+
+type graphQLLanguageEdge struct {
+	Size int64 `json:"size"`
+	Node struct {
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	} `json:"node"`
+}
+
+type graphQLRepoNode struct {
+	Languages struct {
+		Edges []graphQLLanguageEdge `json:"edges"`
+	} `json:"languages"`
+}
+
+type graphQLReposPage struct {
+	PageInfo struct {
+		HasNextPage bool   `json:"hasNextPage"`
+		EndCursor   string `json:"endCursor"`
+	} `json:"pageInfo"`
+	Nodes []graphQLRepoNode `json:"nodes"`
+}
+
+type graphQLResponse struct {
+	Data struct {
+		User *struct {
+			Repositories graphQLReposPage `json:"repositories"`
+		} `json:"user"`
+		Organization *struct {
+			Repositories graphQLReposPage `json:"repositories"`
+		} `json:"organization"`
+	} `json:"data"`
+	Errors []graphQLError `json:"errors"`
+}
+
+// fetchLanguagesGraphQL folds a paginated GraphQL repositories(languages)
+// query into the same map[string]int64 shape fetchLanguages produces, so
+// rankLanguages/collapseOthers/writeSVG don't need to know which API backend
+// fetched the data. GraphQL requires auth, so callers must only take this
+// path when a token is present; the isFork/isArchived query arguments do the
+// fork/archive filtering that the REST path does client-side, since the query
+// accepts it directly.
+func fetchLanguagesGraphQL(client *apiClient, owner, token string, useOrg, includeForks, includeArchived bool) (map[string]int64, error) {
+	field := "user"
+	if useOrg {
+		field = "organization"
+	}
+	var filters strings.Builder
+	if !includeForks {
+		filters.WriteString(", isFork: false")
+	}
+	if !includeArchived {
+		filters.WriteString(", isArchived: false")
+	}
+	query := fmt.Sprintf(reposLanguagesQueryTemplate, field, filters.String())
+
+	total := make(map[string]int64)
+	after := ""
+	for {
+		variables := map[string]any{"login": owner}
+		if after != "" {
+			variables["after"] = after
+		} else {
+			variables["after"] = nil
+		}
+
+		page, err := fetchGraphQLPage(client, token, query, variables, useOrg)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, node := range page.Nodes {
+			for _, edge := range node.Languages.Edges {
+				total[edge.Node.Name] += edge.Size
+				if edge.Node.Color != "" {
+					languageColors[strings.ToLower(edge.Node.Name)] = edge.Node.Color
+				}
+			}
+		}
+
+		if !page.PageInfo.HasNextPage {
+			break
+		}
+		after = page.PageInfo.EndCursor
+	}
+
+	return total, nil
+}
+
+func fetchGraphQLPage(client *apiClient, token, query string, variables map[string]any, useOrg bool) (graphQLReposPage, error) {
+	body, err := json.Marshal(graphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return graphQLReposPage{}, err
+	}
+
+	var parsed graphQLResponse
+	headers := map[string]string{"Content-Type": "application/json"}
+	if err := client.postJSON(graphQLEndpoint, token, body, headers, &parsed); err != nil {
+		return graphQLReposPage{}, fmt.Errorf("graphql request failed: %w", err)
+	}
+	if len(parsed.Errors) > 0 {
+		return graphQLReposPage{}, fmt.Errorf("graphql error: %s", parsed.Errors[0].Message)
+	}
+
+	if useOrg {
+		if parsed.Data.Organization == nil {
+			return graphQLReposPage{}, fmt.Errorf("graphql response missing organization data")
+		}
+		return parsed.Data.Organization.Repositories, nil
+	}
+	if parsed.Data.User == nil {
+		return graphQLReposPage{}, fmt.Errorf("graphql response missing user data")
+	}
+	return parsed.Data.User.Repositories, nil
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultRateLimitFloor is the X-RateLimit-Remaining value at which the
+// client pauses until the window resets, rather than burning the last few
+// requests and getting a hard 403, when the caller doesn't override it.
+const defaultRateLimitFloor = 5
+
+// apiClient wraps http.Client with the behavior repeated runs against the
+// GitHub REST API need: it backs off on rate limits and transient errors,
+// and short-circuits repeat requests with an on-disk ETag cache so a cron
+// job or CI badge refresh costs (close to) nothing against the quota.
+type apiClient struct {
+	http           *http.Client
+	cacheDir       string
+	noCache        bool
+	maxRetries     int
+	rateLimitFloor int64
+}
+
+func newAPIClient(timeout time.Duration, cacheDir string, noCache bool, maxRetries int, rateLimitFloor int64) *apiClient {
+	if rateLimitFloor <= 0 {
+		rateLimitFloor = defaultRateLimitFloor
+	}
+	return &apiClient{
+		http:           &http.Client{Timeout: timeout},
+		cacheDir:       cacheDir,
+		noCache:        noCache,
+		maxRetries:     maxRetries,
+		rateLimitFloor: rateLimitFloor,
+	}
+}
+
+type cacheEntry struct {
+	ETag         string          `json:"etag"`
+	LastModified string          `json:"last_modified"`
+	Body         json.RawMessage `json:"body"`
+}
+
+func (c *apiClient) getJSON(url, token string, target any) error {
+	entry, _ := c.loadCache(url)
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("User-Agent", "github-lang-rank")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if entry != nil {
+			if entry.ETag != "" {
+				req.Header.Set("If-None-Match", entry.ETag)
+			}
+			if entry.LastModified != "" {
+				req.Header.Set("If-Modified-Since", entry.LastModified)
+			}
+		}
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			if attempt < c.maxRetries {
+				time.Sleep(backoff(attempt))
+				continue
+			}
+			return err
+		}
+
+		if retryable(resp.StatusCode) && attempt < c.maxRetries {
+			resp.Body.Close()
+			time.Sleep(backoff(attempt))
+			continue
+		}
+
+		if c.waitForRateLimit(resp, attempt) {
+			resp.Body.Close()
+			continue
+		}
+
+		if resp.StatusCode == http.StatusNotModified {
+			resp.Body.Close()
+			if entry == nil {
+				return fmt.Errorf("304 Not Modified with no cached body for %s", url)
+			}
+			return json.Unmarshal(entry.Body, target)
+		}
+
+		body, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr != nil {
+			return readErr
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("request failed: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+		}
+
+		c.saveCache(url, resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"), body)
+		return json.Unmarshal(body, target)
+	}
+}
+
+// postJSON is getJSON's counterpart for POST bodies (used by the GraphQL
+// path): same retry/backoff and secondary-rate-limit handling, minus the
+// ETag cache, since a POST body varies per call and isn't a good cache key.
+func (c *apiClient) postJSON(url, token string, body []byte, headers map[string]string, target any) error {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("User-Agent", "github-lang-rank")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			if attempt < c.maxRetries {
+				time.Sleep(backoff(attempt))
+				continue
+			}
+			return err
+		}
+
+		if retryable(resp.StatusCode) && attempt < c.maxRetries {
+			resp.Body.Close()
+			time.Sleep(backoff(attempt))
+			continue
+		}
+
+		if c.waitForRateLimit(resp, attempt) {
+			resp.Body.Close()
+			continue
+		}
+
+		respBody, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr != nil {
+			return readErr
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("request failed: %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+		}
+
+		return json.Unmarshal(respBody, target)
+	}
+}
+
+func retryable(status int) bool {
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// waitForRateLimit sleeps until the rate limit window resets when the
+// response says we've hit the floor, or when GitHub has flagged this as a
+// secondary rate limit (403 with a Retry-After). It returns true when the
+// caller should retry the request.
+func (c *apiClient) waitForRateLimit(resp *http.Response, attempt int) bool {
+	if resp.StatusCode == http.StatusForbidden {
+		if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+			if secs, err := strconv.Atoi(retryAfter); err == nil {
+				time.Sleep(time.Duration(secs) * time.Second)
+				return true
+			}
+		}
+	}
+
+	remaining, ok := parseInt64(resp.Header.Get("X-RateLimit-Remaining"))
+	if ok && remaining <= c.rateLimitFloor {
+		if resetAt, ok := parseInt64(resp.Header.Get("X-RateLimit-Reset")); ok {
+			if wait := time.Until(time.Unix(resetAt, 0)); wait > 0 {
+				time.Sleep(wait)
+			}
+			return true
+		}
+	}
+
+	// GitHub's secondary rate limit can return a 403 with no Retry-After
+	// header at all and a primary X-RateLimit-Remaining that's still well
+	// above the floor, so fall back to the same exponential backoff used
+	// for transient 502/503/504s rather than failing hard immediately.
+	if resp.StatusCode == http.StatusForbidden && attempt < c.maxRetries {
+		time.Sleep(backoff(attempt))
+		return true
+	}
+
+	return false
+}
+
+func parseInt64(s string) (int64, bool) {
+	if s == "" {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// backoff computes an exponential delay with jitter for retry attempt n
+// (0-indexed), so concurrent runs don't all retry in lockstep.
+func backoff(attempt int) time.Duration {
+	base := time.Duration(1<<attempt) * 250 * time.Millisecond
+	jitter := time.Duration(rand.Int63n(int64(base) + 1))
+	return base + jitter
+}
+
+func (c *apiClient) cachePath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.cacheDir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *apiClient) loadCache(url string) (*cacheEntry, error) {
+	if c.noCache || c.cacheDir == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(c.cachePath(url))
+	if err != nil {
+		return nil, err
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (c *apiClient) saveCache(url, etag, lastModified string, body []byte) {
+	if c.noCache || c.cacheDir == "" || (etag == "" && lastModified == "") {
+		return
+	}
+	if err := os.MkdirAll(c.cacheDir, 0o755); err != nil {
+		return
+	}
+	entry := cacheEntry{ETag: etag, LastModified: lastModified, Body: json.RawMessage(body)}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(c.cachePath(url), data, 0o644)
+}
+
+func defaultCacheDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".cache", "github-lang-rank")
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// TestGetJSONRetriesSecondaryRateLimit covers the documented GitHub case
+// where a secondary rate limit 403 arrives with no Retry-After header and a
+// primary X-RateLimit-Remaining still above the floor: getJSON must back off
+// and retry rather than returning a hard error.
+func TestGetJSONRetriesSecondaryRateLimit(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("X-RateLimit-Remaining", "100")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"You have exceeded a secondary rate limit"}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	client := newAPIClient(0, "", true, 1, 0)
+	var target struct {
+		OK bool `json:"ok"`
+	}
+	if err := client.getJSON(server.URL, "", &target); err != nil {
+		t.Fatalf("getJSON: %v", err)
+	}
+	if !target.OK {
+		t.Fatal("expected decoded response after retry")
+	}
+	if attempts != 2 {
+		t.Fatalf("expected 2 attempts, got %d", attempts)
+	}
+}
+
+// TestGetJSONGivesUpAfterMaxRetries confirms the secondary-rate-limit
+// fallback is bounded by maxRetries rather than retrying forever against a
+// 403 that never clears (e.g. a genuinely bad token).
+func TestGetJSONGivesUpAfterMaxRetries(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("X-RateLimit-Remaining", "100")
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := newAPIClient(0, "", true, 2, 0)
+	var target any
+	if err := client.getJSON(server.URL, "", &target); err == nil {
+		t.Fatal("expected an error once retries are exhausted")
+	}
+	if attempts != 3 {
+		t.Fatalf("expected maxRetries+1 = 3 attempts, got %d", attempts)
+	}
+}
@@ -2,24 +2,34 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"net/http"
+	"math"
 	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+// repo is the provider-agnostic shape ListRepos returns. ProviderID and
+// SizeBytes are only populated/used by providers that need them: GitLab's
+// LanguageBytes needs the numeric project id and the repository's total
+// size in bytes to convert the percentages its API returns into synthetic
+// byte counts.
 type repo struct {
-	Name     string `json:"name"`
-	FullName string `json:"full_name"`
-	Fork     bool   `json:"fork"`
-	Archived bool   `json:"archived"`
+	Name       string `json:"name"`
+	FullName   string `json:"full_name"`
+	Fork       bool   `json:"fork"`
+	Archived   bool   `json:"archived"`
+	ProviderID int64  `json:"-"`
+	SizeBytes  int64  `json:"-"`
 }
 
 type langStat struct {
@@ -28,6 +38,14 @@ type langStat struct {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		runServe(os.Args[2:])
+		return
+	}
+	runRank()
+}
+
+func runRank() {
 	configPath := &stringFlag{val: "config.json"}
 	username := &stringFlag{}
 	token := &stringFlag{}
@@ -38,6 +56,20 @@ func main() {
 	showOther := &boolFlag{}
 	exclude := &stringFlag{}
 	top := &intFlag{}
+	api := &stringFlag{}
+	format := &stringFlag{}
+	cacheDir := &stringFlag{}
+	noCache := &boolFlag{}
+	maxRetries := &intFlag{}
+	rateLimitFloor := &intFlag{}
+	concurrency := &intFlag{}
+	bestEffort := &boolFlag{}
+	provider := &stringFlag{}
+	baseURL := &stringFlag{}
+	theme := &stringFlag{}
+	layout := &stringFlag{}
+	hideTitle := &boolFlag{}
+	cardTitle := &stringFlag{}
 
 	flag.Var(configPath, "config", "Path to config JSON")
 	flag.Var(username, "username", "GitHub username or org")
@@ -49,6 +81,20 @@ func main() {
 	flag.Var(showOther, "show-other", "Show aggregated Other bucket when top is used")
 	flag.Var(exclude, "exclude", "Comma-separated languages to exclude")
 	flag.Var(top, "top", "Limit to top N languages (0 = all)")
+	flag.Var(api, "api", "API backend to use: rest or graphql (requires -token)")
+	flag.Var(format, "format", "Output format: table, json, csv, tsv, yaml, markdown")
+	flag.Var(cacheDir, "cache-dir", "Directory for the on-disk ETag cache (default ~/.cache/github-lang-rank)")
+	flag.Var(noCache, "no-cache", "Disable the on-disk ETag cache")
+	flag.Var(maxRetries, "max-retries", "Max retries for transient HTTP errors and secondary rate limits")
+	flag.Var(rateLimitFloor, "rate-limit-floor", "X-RateLimit-Remaining value at which to sleep until the window resets")
+	flag.Var(concurrency, "concurrency", "Number of concurrent per-repo language fetches")
+	flag.Var(bestEffort, "best-effort", "Keep results from repos that succeeded even if some fail")
+	flag.Var(provider, "provider", "VCS provider: github, gitlab, or gitea")
+	flag.Var(baseURL, "base-url", "Base URL for self-hosted GitLab/Gitea instances")
+	flag.Var(theme, "theme", "SVG theme: dark, light, dracula, solarized, github")
+	flag.Var(layout, "layout", "SVG layout: bar, donut, compact")
+	flag.Var(hideTitle, "hide-title", "Omit the card title")
+	flag.Var(cardTitle, "card-title", "Custom card title (implies showing the title)")
 	flag.Parse()
 
 	cfg, err := loadConfig(configPath.val)
@@ -56,37 +102,54 @@ func main() {
 		exitWith(err.Error())
 	}
 
-	merged := mergeConfig(cfg, username, token, output, includeForks, includeArchived, useOrg, showOther, exclude, top)
+	merged := mergeConfig(cfg, username, token, output, includeForks, includeArchived, useOrg, showOther, exclude, top, api, format, cacheDir, noCache, maxRetries, rateLimitFloor, concurrency, bestEffort, provider, baseURL, theme, layout, hideTitle, cardTitle)
 
 	if merged.Username == "" {
 		exitWith("missing -username")
 	}
 
-	client := &http.Client{Timeout: 20 * time.Second}
+	client := newAPIClient(20*time.Second, merged.CacheDir, merged.NoCache, merged.MaxRetries, int64(merged.RateLimitFloor))
 
-	repos, err := fetchRepos(client, merged.Username, merged.Token, merged.Org)
-	if err != nil {
-		exitWith(err.Error())
-	}
+	var total map[string]int64
+	if merged.Provider == "github" && merged.API == "graphql" && merged.Token != "" {
+		total, err = fetchLanguagesGraphQL(client, merged.Username, merged.Token, merged.Org, merged.IncludeForks, merged.IncludeArchived)
+		if err != nil {
+			exitWith(err.Error())
+		}
+	} else {
+		provider, err := providerFor(merged.Provider, client, merged.Token, merged.BaseURL)
+		if err != nil {
+			exitWith(err.Error())
+		}
 
-	filtered := make([]repo, 0, len(repos))
-	for _, r := range repos {
-		if !merged.IncludeForks && r.Fork {
-			continue
+		repos, err := provider.ListRepos(context.Background(), merged.Username, merged.Org)
+		if err != nil {
+			exitWith(err.Error())
 		}
-		if !merged.IncludeArchived && r.Archived {
-			continue
+
+		filtered := make([]repo, 0, len(repos))
+		for _, r := range repos {
+			if !merged.IncludeForks && r.Fork {
+				continue
+			}
+			if !merged.IncludeArchived && r.Archived {
+				continue
+			}
+			filtered = append(filtered, r)
 		}
-		filtered = append(filtered, r)
-	}
 
-	if len(filtered) == 0 {
-		exitWith("no repositories after filtering")
+		if len(filtered) == 0 {
+			exitWith("no repositories after filtering")
+		}
+
+		total, err = fetchLanguages(context.Background(), provider, filtered, merged.Concurrency, merged.BestEffort)
+		if err != nil {
+			exitWith(err.Error())
+		}
 	}
 
-	total, err := fetchLanguages(client, filtered, merged.Token)
-	if err != nil {
-		exitWith(err.Error())
+	if len(total) == 0 {
+		exitWith("no language data returned for this account")
 	}
 
 	excluded := applyExcludes(total, merged.Exclude)
@@ -95,9 +158,16 @@ func main() {
 		ranked = collapseOthers(ranked, merged.Top, *merged.ShowOther)
 	}
 
-	printTable(ranked)
+	formatter, err := formatterFor(merged.Format)
+	if err != nil {
+		exitWith(err.Error())
+	}
+	if err := formatter.Format(os.Stdout, merged.Username, ranked, excluded); err != nil {
+		exitWith(err.Error())
+	}
 
-	if err := writeSVG(merged.Output, ranked, merged.Username, excluded); err != nil {
+	opts := svgOptions{Theme: merged.Theme, Layout: merged.Layout, HideTitle: merged.HideTitle && merged.CardTitle == "", CardTitle: merged.CardTitle}
+	if err := writeSVG(merged.Output, ranked, merged.Username, excluded, opts); err != nil {
 		exitWith(err.Error())
 	}
 }
@@ -107,73 +177,92 @@ func exitWith(msg string) {
 	os.Exit(1)
 }
 
-func fetchRepos(client *http.Client, owner, token string, useOrg bool) ([]repo, error) {
-	base := "https://api.github.com"
-	endpoint := fmt.Sprintf("/users/%s/repos", owner)
-	if useOrg {
-		endpoint = fmt.Sprintf("/orgs/%s/repos", owner)
+// fetchLanguages fans the per-repo language calls out over a bounded worker
+// pool instead of fetching them one at a time, since that loop dominates
+// wall time for accounts with many repos. Work stops as soon as a hard
+// error occurs unless bestEffort is set, in which case per-repo errors are
+// collected and only fail the run if every repo failed. provider abstracts
+// over the backend (GitHub/GitLab/Gitea) so this pool doesn't need to know
+// which one it's talking to.
+func fetchLanguages(ctx context.Context, provider Provider, repos []repo, concurrency int, bestEffort bool) (map[string]int64, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type languageResult struct {
+		repo  repo
+		langs map[string]int64
+		err   error
+	}
+
+	jobs := make(chan repo)
+	results := make(chan languageResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range jobs {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+				langs, err := provider.LanguageBytes(ctx, r)
+				select {
+				case results <- languageResult{repo: r, langs: langs, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
 	}
 
-	var all []repo
-	for page := 1; ; page++ {
-		url := fmt.Sprintf("%s%s?per_page=100&page=%d", base, endpoint, page)
-		var batch []repo
-		if err := getJSON(client, url, token, &batch); err != nil {
-			return nil, err
-		}
-		if len(batch) == 0 {
-			break
+	go func() {
+		defer close(jobs)
+		for _, r := range repos {
+			select {
+			case jobs <- r:
+			case <-ctx.Done():
+				return
+			}
 		}
-		all = append(all, batch...)
-	}
-	return all, nil
-}
+	}()
 
-func fetchLanguages(client *http.Client, repos []repo, token string) (map[string]int64, error) {
-	base := "https://api.github.com"
-	total := make(map[string]int64)
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
 
-	for _, r := range repos {
-		url := fmt.Sprintf("%s/repos/%s/languages", base, r.FullName)
-		var langs map[string]int64
-		if err := getJSON(client, url, token, &langs); err != nil {
-			return nil, fmt.Errorf("languages for %s: %w", r.FullName, err)
+	total := make(map[string]int64)
+	succeeded := 0
+	var errs []error
+	for res := range results {
+		if res.err != nil {
+			errs = append(errs, res.err)
+			if !bestEffort {
+				cancel()
+			}
+			continue
 		}
-		for lang, bytes := range langs {
+		succeeded++
+		for lang, bytes := range res.langs {
 			total[lang] += bytes
 		}
 	}
 
-	return total, nil
-}
-
-func getJSON(client *http.Client, url, token string, target any) error {
-	req, err := http.NewRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Accept", "application/vnd.github+json")
-	req.Header.Set("User-Agent", "github-lang-rank")
-	if token != "" {
-		req.Header.Set("Authorization", "Bearer "+token)
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("request failed: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	if len(errs) == 0 {
+		return total, nil
 	}
-
-	dec := json.NewDecoder(resp.Body)
-	if err := dec.Decode(target); err != nil {
-		return err
+	if bestEffort && succeeded > 0 {
+		fmt.Fprintf(os.Stderr, "warning: %d repo(s) skipped: %v\n", len(errs), errors.Join(errs...))
+		return total, nil
 	}
-	return nil
+	return nil, errors.Join(errs...)
 }
 
 func rankLanguages(total map[string]int64) []langStat {
@@ -190,50 +279,116 @@ func rankLanguages(total map[string]int64) []langStat {
 	return stats
 }
 
-func printTable(ranked []langStat) {
-	fmt.Println("Language Bytes")
-	fmt.Println("-------- -----")
+func totalBytesOf(ranked []langStat) int64 {
+	var total int64
 	for _, item := range ranked {
-		fmt.Printf("%-8s %d\n", item.Lang, item.Bytes)
+		total += item.Bytes
 	}
+	return total
 }
 
-func writeSVG(path string, ranked []langStat, owner string, excluded []string) error {
-	if len(ranked) == 0 {
-		return fmt.Errorf("no language data to chart")
+// svgOptions controls the cosmetic parts of the chart (theme, layout,
+// title) that don't change what data is plotted.
+type svgOptions struct {
+	Theme     string // dark (default), light, dracula, solarized, github
+	Layout    string // bar (default), donut, compact
+	HideTitle bool
+	CardTitle string
+}
+
+func (o svgOptions) title() string {
+	if o.CardTitle != "" {
+		return o.CardTitle
 	}
+	return "Most Used Languages"
+}
 
-	const (
-		width       = 640
-		height      = 320
-		cardPadding = 28
-	)
+var svgSeriesColors = []string{
+	"#f2c94c",
+	"#2d9cdb",
+	"#27ae60",
+	"#bb6bd9",
+	"#56ccf2",
+	"#eb5757",
+}
 
-	colors := []string{
-		"#f2c94c",
-		"#2d9cdb",
-		"#27ae60",
-		"#bb6bd9",
-		"#56ccf2",
-		"#eb5757",
+// writeSVG renders the chart to path, creating any missing parent
+// directories. It's a thin wrapper around writeSVGTo for the CLI's
+// file-output mode; the serve subcommand calls writeSVGTo with an in-memory
+// buffer, which it caches and writes to the http.ResponseWriter.
+func writeSVG(path string, ranked []langStat, owner string, excluded []string, opts svgOptions) error {
+	var buf bytes.Buffer
+	if err := writeSVGTo(&buf, ranked, owner, excluded, opts); err != nil {
+		return err
 	}
 
-	totalBytes := int64(0)
-	for _, item := range ranked {
-		totalBytes += item.Bytes
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil && filepath.Dir(path) != "." {
+		return err
 	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+func writeSVGTo(w io.Writer, ranked []langStat, owner string, excluded []string, opts svgOptions) error {
+	if len(ranked) == 0 {
+		return fmt.Errorf("no language data to chart")
+	}
+	totalBytes := totalBytesOf(ranked)
 	if totalBytes == 0 {
 		return fmt.Errorf("no language data to chart")
 	}
 
-	var buf bytes.Buffer
-	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
-	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d">`+"\n", width, height)
-	buf.WriteString(`<rect width="100%" height="100%" rx="14" fill="#202a2f" stroke="#324047" stroke-width="2"/>` + "\n")
-	if len(ranked) == 0 {
-		return fmt.Errorf("no language data to chart")
+	palette, err := themeFor(opts.Theme)
+	if err != nil {
+		return err
 	}
 
+	const width = 640
+
+	switch opts.Layout {
+	case "", "bar":
+		return writeBarLayout(w, ranked, excluded, opts, palette, totalBytes, width)
+	case "donut":
+		return writeDonutLayout(w, ranked, excluded, opts, palette, totalBytes, width)
+	case "compact":
+		return writeCompactLayout(w, ranked, excluded, opts, palette, totalBytes, width)
+	default:
+		return fmt.Errorf("unknown layout %q: want bar, donut, or compact", opts.Layout)
+	}
+}
+
+func svgHeader(w io.Writer, width, height int, palette svgPalette) {
+	io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d">`+"\n", width, height)
+	fmt.Fprintf(w, `<rect width="100%%" height="100%%" rx="14" fill="%s" stroke="%s" stroke-width="2"/>`+"\n", palette.Background, palette.Border)
+}
+
+func svgTitle(w io.Writer, opts svgOptions, palette svgPalette, x, y int) {
+	if opts.HideTitle {
+		return
+	}
+	fmt.Fprintf(w, `<text x="%d" y="%d" font-family="Poppins, 'Segoe UI', Arial, sans-serif" font-size="20" fill="%s">%s</text>`+"\n", x, y, palette.Title, escapeText(opts.title()))
+}
+
+func svgExcludedNote(w io.Writer, excluded []string, palette svgPalette, x, y, maxY int) {
+	if len(excluded) == 0 {
+		return
+	}
+	note := fmt.Sprintf("Excluded: %s", strings.Join(excluded, ", "))
+	if y > maxY {
+		y = maxY
+	}
+	fmt.Fprintf(w, `<text x="%d" y="%d" font-family="Poppins, 'Segoe UI', Arial, sans-serif" font-size="12" fill="%s">%s</text>`+"\n", x, y, palette.Note, escapeText(note))
+}
+
+// writeBarLayout is the original chart: a stacked bar followed by a tile
+// grid with one tile per language. It's kept as the default so existing
+// users see no change.
+func writeBarLayout(w io.Writer, ranked []langStat, excluded []string, opts svgOptions, palette svgPalette, totalBytes int64, width int) error {
+	const (
+		height      = 320
+		cardPadding = 28
+	)
+
 	cols := 3
 	if len(ranked) < cols {
 		cols = len(ranked)
@@ -241,6 +396,9 @@ func writeSVG(path string, ranked []langStat, owner string, excluded []string) e
 	rows := (len(ranked) + cols - 1) / cols
 
 	headerHeight := 24
+	if opts.HideTitle {
+		headerHeight = 0
+	}
 	headerGap := 18
 	barHeight := 14
 	barGap := 22
@@ -257,14 +415,14 @@ func writeSVG(path string, ranked []langStat, owner string, excluded []string) e
 		topOffset = cardPadding
 	}
 
-	headerTextY := topOffset + 18
-	fmt.Fprintf(&buf, `<text x="%d" y="%d" font-family="Poppins, 'Segoe UI', Arial, sans-serif" font-size="20" fill="#9be36a">Most Used Languages</text>`+"\n", cardPadding, headerTextY)
+	svgHeader(w, width, height, palette)
+	svgTitle(w, opts, palette, cardPadding, topOffset+18)
 
 	barX := cardPadding
 	barY := topOffset + headerHeight + headerGap
 	barWidth := width - cardPadding*2
-	fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="%d" height="%d" rx="7" fill="#1b2328"/>`+"\n", barX, barY, barWidth, barHeight)
-	fmt.Fprintf(&buf, `<clipPath id="barClip"><rect x="%d" y="%d" width="%d" height="%d" rx="7"/></clipPath>`+"\n", barX, barY, barWidth, barHeight)
+	fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d" rx="7" fill="%s"/>`+"\n", barX, barY, barWidth, barHeight, palette.Track)
+	fmt.Fprintf(w, `<clipPath id="barClip"><rect x="%d" y="%d" width="%d" height="%d" rx="7"/></clipPath>`+"\n", barX, barY, barWidth, barHeight)
 
 	accumX := barX
 	for i, item := range ranked {
@@ -275,8 +433,8 @@ func writeSVG(path string, ranked []langStat, owner string, excluded []string) e
 		if i == len(ranked)-1 {
 			segmentWidth = barX + barWidth - accumX
 		}
-		color := colorForLanguage(item.Lang, colors[i%len(colors)])
-		fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s" clip-path="url(#barClip)"/>`+"\n", accumX, barY, segmentWidth, barHeight, color)
+		color := colorForLanguage(item.Lang, svgSeriesColors[i%len(svgSeriesColors)])
+		fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s" clip-path="url(#barClip)"/>`+"\n", accumX, barY, segmentWidth, barHeight, color)
 		accumX += segmentWidth
 	}
 
@@ -289,31 +447,163 @@ func writeSVG(path string, ranked []langStat, owner string, excluded []string) e
 		col := i % cols
 		x := cardPadding + col*(tileWidth+tileGap)
 		y := gridTop + row*(tileHeight+tileGap)
-		color := colorForLanguage(item.Lang, colors[i%len(colors)])
+		color := colorForLanguage(item.Lang, svgSeriesColors[i%len(svgSeriesColors)])
 		percent := float64(item.Bytes) / float64(totalBytes) * 100
 
-		fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="%d" height="%d" rx="12" fill="#1b2328" stroke="#2c3a42" stroke-width="1"/>`+"\n", x, y, tileWidth, tileHeight)
-		fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="6" height="%d" rx="3" fill="%s"/>`+"\n", x+12, y+10, tileHeight-20, color)
-		fmt.Fprintf(&buf, `<text x="%d" y="%d" font-family="Poppins, 'Segoe UI', Arial, sans-serif" font-size="16" fill="#d3dde3">%s</text>`+"\n", x+28, y+28, escapeText(item.Lang))
-		fmt.Fprintf(&buf, `<text x="%d" y="%d" font-family="Poppins, 'Segoe UI', Arial, sans-serif" font-size="13" fill="#93a4ac">%.2f%%</text>`+"\n", x+28, y+48, percent)
-		fmt.Fprintf(&buf, `<text x="%d" y="%d" font-family="Poppins, 'Segoe UI', Arial, sans-serif" font-size="12" fill="#6f848e">%d bytes</text>`+"\n", x+28, y+64, item.Bytes)
+		fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d" rx="12" fill="%s" stroke="%s" stroke-width="1"/>`+"\n", x, y, tileWidth, tileHeight, palette.TileBG, palette.TileBorder)
+		fmt.Fprintf(w, `<rect x="%d" y="%d" width="6" height="%d" rx="3" fill="%s"/>`+"\n", x+12, y+10, tileHeight-20, color)
+		fmt.Fprintf(w, `<text x="%d" y="%d" font-family="Poppins, 'Segoe UI', Arial, sans-serif" font-size="16" fill="%s">%s</text>`+"\n", x+28, y+28, palette.TileText, escapeText(item.Lang))
+		fmt.Fprintf(w, `<text x="%d" y="%d" font-family="Poppins, 'Segoe UI', Arial, sans-serif" font-size="13" fill="%s">%.2f%%</text>`+"\n", x+28, y+48, palette.TileSubtext, percent)
+		fmt.Fprintf(w, `<text x="%d" y="%d" font-family="Poppins, 'Segoe UI', Arial, sans-serif" font-size="12" fill="%s">%d bytes</text>`+"\n", x+28, y+64, palette.TileMuted, item.Bytes)
 	}
 
-	if len(excluded) > 0 {
-		note := fmt.Sprintf("Excluded: %s", strings.Join(excluded, ", "))
-		noteY := gridTop + gridHeight + 20
-		if noteY > height-18 {
-			noteY = height - 18
+	svgExcludedNote(w, excluded, palette, cardPadding, gridTop+gridHeight+20, height-18)
+	io.WriteString(w, `</svg>`+"\n")
+	return nil
+}
+
+// writeCompactLayout renders the same stacked bar but skips the tile grid
+// in favor of a single-line legend, for embedding where vertical space is
+// tight.
+func writeCompactLayout(w io.Writer, ranked []langStat, excluded []string, opts svgOptions, palette svgPalette, totalBytes int64, width int) error {
+	const (
+		height      = 120
+		cardPadding = 28
+	)
+
+	headerHeight := 24
+	if opts.HideTitle {
+		headerHeight = 0
+	}
+	headerGap := 14
+	barHeight := 14
+	barGap := 18
+
+	svgHeader(w, width, height, palette)
+	topOffset := cardPadding
+	svgTitle(w, opts, palette, cardPadding, topOffset+18)
+
+	barX := cardPadding
+	barY := topOffset + headerHeight + headerGap
+	barWidth := width - cardPadding*2
+	fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d" rx="7" fill="%s"/>`+"\n", barX, barY, barWidth, barHeight, palette.Track)
+	fmt.Fprintf(w, `<clipPath id="barClip"><rect x="%d" y="%d" width="%d" height="%d" rx="7"/></clipPath>`+"\n", barX, barY, barWidth, barHeight)
+
+	accumX := barX
+	for i, item := range ranked {
+		segmentWidth := int(float64(barWidth) * (float64(item.Bytes) / float64(totalBytes)))
+		if segmentWidth == 0 {
+			continue
 		}
-		fmt.Fprintf(&buf, `<text x="%d" y="%d" font-family="Poppins, 'Segoe UI', Arial, sans-serif" font-size="12" fill="#93a4ac">%s</text>`+"\n", cardPadding, noteY, escapeText(note))
+		if i == len(ranked)-1 {
+			segmentWidth = barX + barWidth - accumX
+		}
+		color := colorForLanguage(item.Lang, svgSeriesColors[i%len(svgSeriesColors)])
+		fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s" clip-path="url(#barClip)"/>`+"\n", accumX, barY, segmentWidth, barHeight, color)
+		accumX += segmentWidth
 	}
 
-	buf.WriteString(`</svg>` + "\n")
+	legendY := barY + barHeight + barGap + 10
+	legendX := cardPadding
+	legendMaxX := width - cardPadding
+	for i, item := range ranked {
+		label := fmt.Sprintf("%s %.1f%%", item.Lang, float64(item.Bytes)/float64(totalBytes)*100)
+		entryWidth := 16 + len(label)*7 + 18
+		if legendX+entryWidth > legendMaxX && i < len(ranked)-1 {
+			fmt.Fprintf(w, `<text x="%d" y="%d" font-family="Poppins, 'Segoe UI', Arial, sans-serif" font-size="12" fill="%s">+%d more</text>`+"\n", legendX, legendY, palette.TileMuted, len(ranked)-i)
+			break
+		}
+		color := colorForLanguage(item.Lang, svgSeriesColors[i%len(svgSeriesColors)])
+		fmt.Fprintf(w, `<circle cx="%d" cy="%d" r="5" fill="%s"/>`+"\n", legendX+5, legendY-5, color)
+		fmt.Fprintf(w, `<text x="%d" y="%d" font-family="Poppins, 'Segoe UI', Arial, sans-serif" font-size="12" fill="%s">%s</text>`+"\n", legendX+16, legendY, palette.TileText, escapeText(label))
+		legendX += entryWidth
+	}
 
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil && filepath.Dir(path) != "." {
-		return err
+	svgExcludedNote(w, excluded, palette, cardPadding, height-18, height-18)
+	io.WriteString(w, `</svg>`+"\n")
+	return nil
+}
+
+// writeDonutLayout replaces the bar+grid with a donut chart: one arc per
+// language, sized proportionally to its share of totalBytes, plus a
+// legend listing each language's percentage.
+func writeDonutLayout(w io.Writer, ranked []langStat, excluded []string, opts svgOptions, palette svgPalette, totalBytes int64, width int) error {
+	const (
+		height      = 320
+		cardPadding = 28
+	)
+
+	headerHeight := 24
+	if opts.HideTitle {
+		headerHeight = 0
 	}
-	return os.WriteFile(path, buf.Bytes(), 0o644)
+	headerGap := 18
+
+	svgHeader(w, width, height, palette)
+	topOffset := cardPadding
+	svgTitle(w, opts, palette, cardPadding, topOffset+18)
+
+	centerX := 140
+	centerY := topOffset + headerHeight + headerGap + 110
+	outerRadius := 100.0
+	innerRadius := 58.0
+
+	angle := -math.Pi / 2
+	for i, item := range ranked {
+		fraction := float64(item.Bytes) / float64(totalBytes)
+		sweep := fraction * 2 * math.Pi
+		color := colorForLanguage(item.Lang, svgSeriesColors[i%len(svgSeriesColors)])
+		fmt.Fprint(w, donutSectorPath(float64(centerX), float64(centerY), innerRadius, outerRadius, angle, angle+sweep, color))
+		angle += sweep
+	}
+
+	legendX := centerX + int(outerRadius) + 36
+	legendY := topOffset + headerHeight + headerGap + 14
+	for i, item := range ranked {
+		color := colorForLanguage(item.Lang, svgSeriesColors[i%len(svgSeriesColors)])
+		percent := float64(item.Bytes) / float64(totalBytes) * 100
+		fmt.Fprintf(w, `<circle cx="%d" cy="%d" r="5" fill="%s"/>`+"\n", legendX+5, legendY-4, color)
+		fmt.Fprintf(w, `<text x="%d" y="%d" font-family="Poppins, 'Segoe UI', Arial, sans-serif" font-size="13" fill="%s">%s %.1f%%</text>`+"\n", legendX+18, legendY, palette.TileText, escapeText(item.Lang), percent)
+		legendY += 22
+	}
+
+	svgExcludedNote(w, excluded, palette, cardPadding, height-18, height-18)
+	io.WriteString(w, `</svg>`+"\n")
+	return nil
+}
+
+// donutSectorPath renders one <path> arc sector between angles start and
+// end (radians, 0 = +x axis), as a ring between innerRadius and
+// outerRadius around (cx, cy). A full-circle sweep (a single language at
+// 100% of totalBytes) is split into two half-circle arcs, since an SVG
+// arc whose start and end points coincide is degenerate and gets dropped.
+func donutSectorPath(cx, cy, innerRadius, outerRadius, start, end float64, color string) string {
+	if end-start >= 2*math.Pi-1e-9 {
+		mid := start + math.Pi
+		return donutSectorPath(cx, cy, innerRadius, outerRadius, start, mid, color) +
+			donutSectorPath(cx, cy, innerRadius, outerRadius, mid, end, color)
+	}
+
+	largeArc := 0
+	if end-start > math.Pi {
+		largeArc = 1
+	}
+
+	outerStartX := cx + outerRadius*math.Cos(start)
+	outerStartY := cy + outerRadius*math.Sin(start)
+	outerEndX := cx + outerRadius*math.Cos(end)
+	outerEndY := cy + outerRadius*math.Sin(end)
+	innerStartX := cx + innerRadius*math.Cos(end)
+	innerStartY := cy + innerRadius*math.Sin(end)
+	innerEndX := cx + innerRadius*math.Cos(start)
+	innerEndY := cy + innerRadius*math.Sin(start)
+
+	return fmt.Sprintf(
+		`<path d="M %.2f %.2f A %.2f %.2f 0 %d 1 %.2f %.2f L %.2f %.2f A %.2f %.2f 0 %d 0 %.2f %.2f Z" fill="%s"/>`+"\n",
+		outerStartX, outerStartY, outerRadius, outerRadius, largeArc, outerEndX, outerEndY,
+		innerStartX, innerStartY, innerRadius, innerRadius, largeArc, innerEndX, innerEndY,
+		color,
+	)
 }
 
 func escapeText(input string) string {
@@ -394,15 +684,29 @@ func collapseOthers(ranked []langStat, top int, showOther bool) []langStat {
 }
 
 type config struct {
-	Username        string `json:"username"`
-	Token           string `json:"token"`
-	Output          string `json:"output"`
-	IncludeForks    bool   `json:"include_forks"`
-	IncludeArchived bool   `json:"include_archived"`
-	Org             bool   `json:"org"`
-	ShowOther       *bool  `json:"show_other"`
+	Username        string   `json:"username"`
+	Token           string   `json:"token"`
+	Output          string   `json:"output"`
+	IncludeForks    bool     `json:"include_forks"`
+	IncludeArchived bool     `json:"include_archived"`
+	Org             bool     `json:"org"`
+	ShowOther       *bool    `json:"show_other"`
 	Exclude         []string `json:"exclude"`
-	Top             int    `json:"top"`
+	Top             int      `json:"top"`
+	API             string   `json:"api"`
+	Format          string   `json:"format"`
+	CacheDir        string   `json:"cache_dir"`
+	NoCache         bool     `json:"no_cache"`
+	MaxRetries      int      `json:"max_retries"`
+	RateLimitFloor  int      `json:"rate_limit_floor"`
+	Concurrency     int      `json:"concurrency"`
+	BestEffort      bool     `json:"best_effort"`
+	Provider        string   `json:"provider"`
+	BaseURL         string   `json:"base_url"`
+	Theme           string   `json:"theme"`
+	Layout          string   `json:"layout"`
+	HideTitle       bool     `json:"hide_title"`
+	CardTitle       string   `json:"card_title"`
 }
 
 func loadConfig(path string) (config, error) {
@@ -423,7 +727,7 @@ func loadConfig(path string) (config, error) {
 	return cfg, nil
 }
 
-func mergeConfig(cfg config, username, token, output *stringFlag, includeForks, includeArchived, useOrg, showOther *boolFlag, exclude *stringFlag, top *intFlag) config {
+func mergeConfig(cfg config, username, token, output *stringFlag, includeForks, includeArchived, useOrg, showOther *boolFlag, exclude *stringFlag, top *intFlag, api, format, cacheDir *stringFlag, noCache *boolFlag, maxRetries, rateLimitFloor, concurrency *intFlag, bestEffort *boolFlag, provider, baseURL, theme, layout *stringFlag, hideTitle *boolFlag, cardTitle *stringFlag) config {
 	merged := cfg
 
 	if username.set {
@@ -454,6 +758,48 @@ func mergeConfig(cfg config, username, token, output *stringFlag, includeForks,
 	if top.set {
 		merged.Top = top.val
 	}
+	if api.set {
+		merged.API = api.val
+	}
+	if format.set {
+		merged.Format = format.val
+	}
+	if cacheDir.set {
+		merged.CacheDir = cacheDir.val
+	}
+	if noCache.set {
+		merged.NoCache = noCache.val
+	}
+	if maxRetries.set {
+		merged.MaxRetries = maxRetries.val
+	}
+	if rateLimitFloor.set {
+		merged.RateLimitFloor = rateLimitFloor.val
+	}
+	if concurrency.set {
+		merged.Concurrency = concurrency.val
+	}
+	if bestEffort.set {
+		merged.BestEffort = bestEffort.val
+	}
+	if provider.set {
+		merged.Provider = provider.val
+	}
+	if baseURL.set {
+		merged.BaseURL = baseURL.val
+	}
+	if theme.set {
+		merged.Theme = theme.val
+	}
+	if layout.set {
+		merged.Layout = layout.val
+	}
+	if hideTitle.set {
+		merged.HideTitle = hideTitle.val
+	}
+	if cardTitle.set {
+		merged.CardTitle = cardTitle.val
+	}
 
 	if merged.Output == "" {
 		merged.Output = "lang-rank.svg"
@@ -462,6 +808,27 @@ func mergeConfig(cfg config, username, token, output *stringFlag, includeForks,
 		defaultOther := true
 		merged.ShowOther = &defaultOther
 	}
+	if merged.API == "" {
+		merged.API = "rest"
+	}
+	if merged.Format == "" {
+		merged.Format = "table"
+	}
+	if merged.CacheDir == "" {
+		merged.CacheDir = defaultCacheDir()
+	}
+	if !maxRetries.set && merged.MaxRetries == 0 {
+		merged.MaxRetries = 3
+	}
+	if !rateLimitFloor.set && merged.RateLimitFloor == 0 {
+		merged.RateLimitFloor = defaultRateLimitFloor
+	}
+	if merged.Concurrency == 0 {
+		merged.Concurrency = 8
+	}
+	if merged.Provider == "" {
+		merged.Provider = "github"
+	}
 
 	return merged
 }
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// Provider abstracts over a VCS host's repo listing and per-repo language
+// breakdown, so rankLanguages/writeSVG/the formatters never need to know
+// whether the data came from GitHub, GitLab, or Gitea.
+type Provider interface {
+	ListRepos(ctx context.Context, owner string, useOrg bool) ([]repo, error)
+	LanguageBytes(ctx context.Context, r repo) (map[string]int64, error)
+}
+
+func providerFor(name string, client *apiClient, token, baseURL string) (Provider, error) {
+	switch name {
+	case "", "github":
+		return &githubProvider{client: client, token: token, baseURL: orDefault(baseURL, "https://api.github.com")}, nil
+	case "gitlab":
+		return &gitlabProvider{client: client, token: token, baseURL: orDefault(baseURL, "https://gitlab.com")}, nil
+	case "gitea":
+		return &giteaProvider{client: client, token: token, baseURL: orDefault(baseURL, "https://gitea.com")}, nil
+	default:
+		return nil, fmt.Errorf("unknown provider %q: want github, gitlab, or gitea", name)
+	}
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// githubProvider is the GitHub REST backend (repo list plus one /languages
+// call per repo) behind the Provider interface, alongside GitLab and Gitea.
+type githubProvider struct {
+	client  *apiClient
+	token   string
+	baseURL string
+}
+
+func (p *githubProvider) ListRepos(ctx context.Context, owner string, useOrg bool) ([]repo, error) {
+	endpoint := fmt.Sprintf("/users/%s/repos", owner)
+	if useOrg {
+		endpoint = fmt.Sprintf("/orgs/%s/repos", owner)
+	}
+
+	var all []repo
+	for page := 1; ; page++ {
+		url := fmt.Sprintf("%s%s?per_page=100&page=%d", p.baseURL, endpoint, page)
+		var batch []repo
+		if err := p.client.getJSON(url, p.token, &batch); err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		all = append(all, batch...)
+	}
+	return all, nil
+}
+
+func (p *githubProvider) LanguageBytes(ctx context.Context, r repo) (map[string]int64, error) {
+	url := fmt.Sprintf("%s/repos/%s/languages", p.baseURL, r.FullName)
+	var langs map[string]int64
+	if err := p.client.getJSON(url, p.token, &langs); err != nil {
+		return nil, fmt.Errorf("languages for %s: %w", r.FullName, err)
+	}
+	return langs, nil
+}
+
+// gitlabProvider talks to the GitLab REST API. GitLab's languages endpoint
+// returns percentages rather than byte counts, so LanguageBytes converts
+// using the project's repository_size (fetched via ?statistics=true on the
+// project list).
+type gitlabProvider struct {
+	client  *apiClient
+	token   string
+	baseURL string
+}
+
+type gitlabUser struct {
+	ID int64 `json:"id"`
+}
+
+type gitlabProject struct {
+	ID                int64  `json:"id"`
+	Name              string `json:"name"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	Archived          bool   `json:"archived"`
+	ForkedFromProject *struct {
+		ID int64 `json:"id"`
+	} `json:"forked_from_project"`
+	Statistics *struct {
+		RepositorySize int64 `json:"repository_size"`
+	} `json:"statistics"`
+}
+
+func (p *gitlabProvider) ListRepos(ctx context.Context, owner string, useOrg bool) ([]repo, error) {
+	projectsEndpoint := fmt.Sprintf("%s/api/v4/groups/%s/projects", p.baseURL, url.PathEscape(owner))
+	if !useOrg {
+		var users []gitlabUser
+		lookupURL := fmt.Sprintf("%s/api/v4/users?username=%s", p.baseURL, owner)
+		if err := p.client.getJSON(lookupURL, p.token, &users); err != nil {
+			return nil, err
+		}
+		if len(users) == 0 {
+			return nil, fmt.Errorf("gitlab: no user found for %q", owner)
+		}
+		projectsEndpoint = fmt.Sprintf("%s/api/v4/users/%d/projects", p.baseURL, users[0].ID)
+	}
+
+	var all []repo
+	for page := 1; ; page++ {
+		url := fmt.Sprintf("%s?per_page=100&page=%d&statistics=true", projectsEndpoint, page)
+		var batch []gitlabProject
+		if err := p.client.getJSON(url, p.token, &batch); err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		for _, project := range batch {
+			r := repo{
+				Name:       project.Name,
+				FullName:   project.PathWithNamespace,
+				Fork:       project.ForkedFromProject != nil,
+				Archived:   project.Archived,
+				ProviderID: project.ID,
+			}
+			if project.Statistics != nil {
+				r.SizeBytes = project.Statistics.RepositorySize
+			}
+			all = append(all, r)
+		}
+	}
+	return all, nil
+}
+
+func (p *gitlabProvider) LanguageBytes(ctx context.Context, r repo) (map[string]int64, error) {
+	url := fmt.Sprintf("%s/api/v4/projects/%d/languages", p.baseURL, r.ProviderID)
+	var percentages map[string]float64
+	if err := p.client.getJSON(url, p.token, &percentages); err != nil {
+		return nil, fmt.Errorf("languages for %s: %w", r.FullName, err)
+	}
+
+	bytes := make(map[string]int64, len(percentages))
+	for lang, percent := range percentages {
+		bytes[lang] = int64(percent / 100 * float64(r.SizeBytes))
+	}
+	return bytes, nil
+}
+
+// giteaProvider talks to the Gitea REST API, whose shapes are close enough
+// to GitHub's that it only needs different paths and a different page-size
+// parameter.
+type giteaProvider struct {
+	client  *apiClient
+	token   string
+	baseURL string
+}
+
+type giteaRepo struct {
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	Fork     bool   `json:"fork"`
+	Archived bool   `json:"archived"`
+}
+
+func (p *giteaProvider) ListRepos(ctx context.Context, owner string, useOrg bool) ([]repo, error) {
+	reposEndpoint := fmt.Sprintf("%s/api/v1/users/%s/repos", p.baseURL, owner)
+	if useOrg {
+		reposEndpoint = fmt.Sprintf("%s/api/v1/orgs/%s/repos", p.baseURL, owner)
+	}
+
+	var all []repo
+	for page := 1; ; page++ {
+		url := fmt.Sprintf("%s?limit=50&page=%d", reposEndpoint, page)
+		var batch []giteaRepo
+		if err := p.client.getJSON(url, p.token, &batch); err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		for _, g := range batch {
+			all = append(all, repo{Name: g.Name, FullName: g.FullName, Fork: g.Fork, Archived: g.Archived})
+		}
+	}
+	return all, nil
+}
+
+func (p *giteaProvider) LanguageBytes(ctx context.Context, r repo) (map[string]int64, error) {
+	url := fmt.Sprintf("%s/api/v1/repos/%s/languages", p.baseURL, r.FullName)
+	var langs map[string]int64
+	if err := p.client.getJSON(url, p.token, &langs); err != nil {
+		return nil, fmt.Errorf("languages for %s: %w", r.FullName, err)
+	}
+	return langs, nil
+}
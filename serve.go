@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// runServe implements the `github-lang-rank serve` subcommand: a small HTTP
+// server that renders the same SVG card on demand, so the tool can act as a
+// self-hosted README badge service instead of a one-shot CLI.
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "Address to listen on")
+	ttl := fs.Int("ttl", 300, "Cache-Control max-age and in-process cache TTL, in seconds")
+	cacheSize := fs.Int("cache-size", 256, "Max number of rendered SVGs to keep in the in-process LRU")
+	cacheDir := fs.String("cache-dir", defaultCacheDir(), "Directory for the on-disk ETag cache")
+	noCache := fs.Bool("no-cache", false, "Disable the on-disk ETag cache")
+	maxRetries := fs.Int("max-retries", 3, "Max retries for transient HTTP errors and secondary rate limits")
+	rateLimitFloor := fs.Int("rate-limit-floor", defaultRateLimitFloor, "X-RateLimit-Remaining value at which to sleep until the window resets")
+	concurrency := fs.Int("concurrency", 8, "Number of concurrent per-repo language fetches")
+	providerName := fs.String("provider", "github", "VCS provider: github, gitlab, or gitea")
+	baseURL := fs.String("base-url", "", "Base URL for self-hosted GitLab/Gitea instances")
+	if err := fs.Parse(args); err != nil {
+		exitWith(err.Error())
+	}
+
+	client := newAPIClient(20*time.Second, *cacheDir, *noCache, *maxRetries, int64(*rateLimitFloor))
+	provider, err := providerFor(*providerName, client, os.Getenv("GITHUB_TOKEN"), *baseURL)
+	if err != nil {
+		exitWith(err.Error())
+	}
+
+	srv := &svgServer{
+		provider:    provider,
+		ttl:         time.Duration(*ttl) * time.Second,
+		cache:       newSVGCache(*cacheSize),
+		concurrency: *concurrency,
+	}
+
+	http.HandleFunc("/svg", srv.handleSVG)
+	fmt.Fprintf(os.Stderr, "github-lang-rank serve: listening on %s\n", *addr)
+	if err := http.ListenAndServe(*addr, nil); err != nil {
+		exitWith(err.Error())
+	}
+}
+
+type svgServer struct {
+	provider    Provider
+	ttl         time.Duration
+	cache       *svgCache
+	concurrency int
+}
+
+func (s *svgServer) handleSVG(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	username := q.Get("user")
+	if username == "" {
+		http.Error(w, "missing user query parameter", http.StatusBadRequest)
+		return
+	}
+	useOrg, _ := strconv.ParseBool(q.Get("org"))
+	top, _ := strconv.Atoi(q.Get("top"))
+	exclude := splitCSV(q.Get("exclude"))
+	hideTitle, _ := strconv.ParseBool(q.Get("hide-title"))
+	opts := svgOptions{
+		Theme:     q.Get("theme"),
+		Layout:    q.Get("layout"),
+		HideTitle: hideTitle,
+		CardTitle: q.Get("card-title"),
+	}
+
+	cacheKey := r.URL.RawQuery
+	if cached, ok := s.cache.get(cacheKey); ok {
+		s.writeSVGResponse(w, r, cached)
+		return
+	}
+
+	repos, err := s.provider.ListRepos(r.Context(), username, useOrg)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	filtered := make([]repo, 0, len(repos))
+	for _, repository := range repos {
+		if !repository.Fork && !repository.Archived {
+			filtered = append(filtered, repository)
+		}
+	}
+	if len(filtered) == 0 {
+		http.Error(w, "no repositories after filtering", http.StatusNotFound)
+		return
+	}
+
+	total, err := fetchLanguages(r.Context(), s.provider, filtered, s.concurrency, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	excluded := applyExcludes(total, exclude)
+	ranked := rankLanguages(total)
+	if top > 0 && top < len(ranked) {
+		ranked = collapseOthers(ranked, top, true)
+	}
+
+	var buf bytes.Buffer
+	if err := writeSVGTo(&buf, ranked, username, excluded, opts); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rendered := buf.Bytes()
+
+	s.cache.set(cacheKey, rendered, s.ttl)
+	s.writeSVGResponse(w, r, rendered)
+}
+
+func (s *svgServer) writeSVGResponse(w http.ResponseWriter, r *http.Request, body []byte) {
+	etag := svgETag(body)
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(s.ttl.Seconds())))
+	w.Header().Set("ETag", etag)
+
+	if match := r.Header.Get("If-None-Match"); match != "" && match == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Write(body)
+}
+
+// svgETag derives a strong ETag from the rendered bytes so GitHub's camo
+// proxy and browsers can revalidate without re-rendering.
+func svgETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+type cacheItem struct {
+	key     string
+	body    []byte
+	expires time.Time
+}
+
+// svgCache is a small in-process LRU keyed by the request's raw query
+// string, so repeat hits (e.g. GitHub re-fetching the same badge) skip the
+// API calls and re-render entirely until ttl elapses.
+type svgCache struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+}
+
+func newSVGCache(capacity int) *svgCache {
+	return &svgCache{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (c *svgCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheItem)
+	if time.Now().After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.items, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.body, true
+}
+
+func (c *svgCache) set(key string, body []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*cacheItem).body = body
+		elem.Value.(*cacheItem).expires = time.Now().Add(ttl)
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	elem := c.order.PushFront(&cacheItem{key: key, body: body, expires: time.Now().Add(ttl)})
+	c.items[key] = elem
+
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		if oldest == nil {
+			break
+		}
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheItem).key)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import "fmt"
+
+// svgPalette is the set of colors a theme needs to supply; every layout
+// pulls from the same palette so adding a theme doesn't require touching
+// layout code.
+type svgPalette struct {
+	Background  string
+	Border      string
+	Title       string
+	Track       string
+	TileBG      string
+	TileBorder  string
+	TileText    string
+	TileSubtext string
+	TileMuted   string
+	Note        string
+}
+
+var svgThemes = map[string]svgPalette{
+	"dark": {
+		Background: "#202a2f", Border: "#324047", Title: "#9be36a",
+		Track: "#1b2328", TileBG: "#1b2328", TileBorder: "#2c3a42",
+		TileText: "#d3dde3", TileSubtext: "#93a4ac", TileMuted: "#6f848e", Note: "#93a4ac",
+	},
+	"light": {
+		Background: "#ffffff", Border: "#d0d7de", Title: "#1f6feb",
+		Track: "#f6f8fa", TileBG: "#f6f8fa", TileBorder: "#d0d7de",
+		TileText: "#24292f", TileSubtext: "#57606a", TileMuted: "#6e7781", Note: "#57606a",
+	},
+	"dracula": {
+		Background: "#282a36", Border: "#44475a", Title: "#50fa7b",
+		Track: "#21222c", TileBG: "#21222c", TileBorder: "#44475a",
+		TileText: "#f8f8f2", TileSubtext: "#bd93f9", TileMuted: "#6272a4", Note: "#bd93f9",
+	},
+	"solarized": {
+		Background: "#002b36", Border: "#073642", Title: "#b58900",
+		Track: "#073642", TileBG: "#073642", TileBorder: "#586e75",
+		TileText: "#eee8d5", TileSubtext: "#93a1a1", TileMuted: "#657b83", Note: "#93a1a1",
+	},
+	"github": {
+		Background: "#0d1117", Border: "#30363d", Title: "#58a6ff",
+		Track: "#161b22", TileBG: "#161b22", TileBorder: "#30363d",
+		TileText: "#c9d1d9", TileSubtext: "#8b949e", TileMuted: "#6e7681", Note: "#8b949e",
+	},
+}
+
+func themeFor(name string) (svgPalette, error) {
+	if name == "" {
+		name = "dark"
+	}
+	palette, ok := svgThemes[name]
+	if !ok {
+		return svgPalette{}, fmt.Errorf("unknown theme %q: want dark, light, dracula, solarized, or github", name)
+	}
+	return palette, nil
+}
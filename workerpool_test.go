@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fakeProvider lets tests control LanguageBytes per-repo without touching
+// the network; ListRepos is unused by fetchLanguages and left unimplemented.
+type fakeProvider struct {
+	fail map[string]error
+}
+
+func (fakeProvider) ListRepos(ctx context.Context, owner string, useOrg bool) ([]repo, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (p fakeProvider) LanguageBytes(ctx context.Context, r repo) (map[string]int64, error) {
+	if err, ok := p.fail[r.FullName]; ok {
+		return nil, err
+	}
+	return map[string]int64{"Go": 100}, nil
+}
+
+// TestFetchLanguagesCancelsOnHardFailure checks that a single repo failure
+// without -best-effort fails the whole call instead of returning partial data.
+func TestFetchLanguagesCancelsOnHardFailure(t *testing.T) {
+	provider := fakeProvider{fail: map[string]error{"owner/bad": errors.New("boom")}}
+	repos := []repo{{FullName: "owner/good"}, {FullName: "owner/bad"}}
+
+	_, err := fetchLanguages(context.Background(), provider, repos, 1, false)
+	if err == nil {
+		t.Fatal("expected an error when a repo fails without best-effort")
+	}
+}
+
+// TestFetchLanguagesBestEffortKeepsSuccesses checks that -best-effort tolerates
+// a failing repo and still aggregates bytes from the repos that succeeded.
+func TestFetchLanguagesBestEffortKeepsSuccesses(t *testing.T) {
+	provider := fakeProvider{fail: map[string]error{"owner/bad": errors.New("boom")}}
+	repos := []repo{{FullName: "owner/good"}, {FullName: "owner/bad"}}
+
+	total, err := fetchLanguages(context.Background(), provider, repos, 2, true)
+	if err != nil {
+		t.Fatalf("fetchLanguages: %v", err)
+	}
+	if total["Go"] != 100 {
+		t.Fatalf("expected 100 bytes of Go from the surviving repo, got %d", total["Go"])
+	}
+}